@@ -0,0 +1,143 @@
+package main
+
+// Basis approximates the continuation-value surface used in the LSM
+// regression. Implementations evaluate a set of basis functions of the
+// two state variables (cost-to-completion and cash flow) at a point.
+// Lsm calls Eval with those state variables scaled to roughly unit
+// magnitude (see ProjectProcess.basisInputs), which every family here
+// assumes: NewWeightedLaguerreBasis in particular is unusable on raw,
+// currency-denominated inputs, since e^(-x/2) underflows to zero well
+// before x reaches the project's typical cost or cash-flow scale.
+type Basis interface {
+	// Size returns the number of basis functions produced by Eval.
+	Size() int
+	// Eval writes the basis function values at (x, y) into out, which
+	// must have length Size().
+	Eval(x, y float64, out []float64)
+}
+
+// tensorBasis builds a bivariate basis as the outer product of a 1-D
+// polynomial family evaluated independently in x and y up to order,
+// generalising the original hardcoded degree-2 monomial basis
+// (1, x, y, xy, x^2, y^2, x^2y, xy^2, x^2y^2) to an arbitrary order and
+// polynomial family. px and py are scratch space for Eval, preallocated
+// once by newTensorBasis rather than per call, since Eval runs in Lsm's
+// innermost per-path, per-period loop.
+type tensorBasis struct {
+	order      int
+	univariate func(x float64, order int, out []float64)
+	px, py     []float64
+}
+
+// newTensorBasis builds a tensorBasis over univariate for the given
+// order, preallocating its Eval scratch space.
+func newTensorBasis(order int, univariate func(x float64, order int, out []float64)) tensorBasis {
+	return tensorBasis{order: order, univariate: univariate, px: make([]float64, order+1), py: make([]float64, order+1)}
+}
+
+// Size returns (order+1)^2, the number of cross terms in the tensor product.
+func (b tensorBasis) Size() int { return (b.order + 1) * (b.order + 1) }
+
+// Eval writes the outer product of the univariate family evaluated at x
+// and at y into out.
+func (b tensorBasis) Eval(x, y float64, out []float64) {
+	n := b.order + 1
+	b.univariate(x, b.order, b.px)
+	b.univariate(y, b.order, b.py)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			out[i*n+j] = b.px[i] * b.py[j]
+		}
+	}
+}
+
+// NewMonomialBasis returns the raw power basis 1, x, x^2, ..., x^order
+// tensored with the same family in y. This is the family the model used
+// implicitly before PolynomialOrder was honored.
+func NewMonomialBasis(order int) Basis {
+	return newTensorBasis(order, monomialUnivariate)
+}
+
+// NewWeightedLaguerreBasis returns weighted Laguerre polynomials
+// L_k(x)*e^(-x/2), the classical Longstaff-Schwartz choice for
+// cash-flow-like state variables that are bounded below at zero.
+func NewWeightedLaguerreBasis(order int) Basis {
+	return newTensorBasis(order, weightedLaguerreUnivariate)
+}
+
+// NewHermiteBasis returns probabilists' Hermite polynomials, a natural
+// fit for log-transformed, approximately Gaussian state variables.
+func NewHermiteBasis(order int) Basis {
+	return newTensorBasis(order, hermiteUnivariate)
+}
+
+// NewChebyshevBasis returns Chebyshev polynomials of the first kind.
+func NewChebyshevBasis(order int) Basis {
+	return newTensorBasis(order, chebyshevUnivariate)
+}
+
+// NewLegendreBasis returns Legendre polynomials.
+func NewLegendreBasis(order int) Basis {
+	return newTensorBasis(order, legendreUnivariate)
+}
+
+// monomialUnivariate fills out[k] = x^k for k in [0, order].
+func monomialUnivariate(x float64, order int, out []float64) {
+	out[0] = 1
+	for k := 1; k <= order; k++ {
+		out[k] = out[k-1] * x
+	}
+}
+
+// weightedLaguerreUnivariate fills out[k] = L_k(x)*e^(-x/2), with L_k the
+// Laguerre polynomials given by the standard three-term recurrence
+// (k+1)*L_{k+1}(x) = (2k+1-x)*L_k(x) - k*L_{k-1}(x).
+func weightedLaguerreUnivariate(x float64, order int, out []float64) {
+	out[0] = 1
+	if order > 0 {
+		out[1] = 1 - x
+	}
+	for k := 1; k < order; k++ {
+		out[k+1] = ((float64(2*k+1)-x)*out[k] - float64(k)*out[k-1]) / float64(k+1)
+	}
+	w := exp(-x / 2)
+	for k := range out[:order+1] {
+		out[k] *= w
+	}
+}
+
+// hermiteUnivariate fills out[k] = He_k(x), the probabilists' Hermite
+// polynomials given by He_{k+1}(x) = x*He_k(x) - k*He_{k-1}(x).
+func hermiteUnivariate(x float64, order int, out []float64) {
+	out[0] = 1
+	if order > 0 {
+		out[1] = x
+	}
+	for k := 1; k < order; k++ {
+		out[k+1] = x*out[k] - float64(k)*out[k-1]
+	}
+}
+
+// chebyshevUnivariate fills out[k] = T_k(x), the Chebyshev polynomials of
+// the first kind given by T_{k+1}(x) = 2x*T_k(x) - T_{k-1}(x).
+func chebyshevUnivariate(x float64, order int, out []float64) {
+	out[0] = 1
+	if order > 0 {
+		out[1] = x
+	}
+	for k := 1; k < order; k++ {
+		out[k+1] = 2*x*out[k] - out[k-1]
+	}
+}
+
+// legendreUnivariate fills out[k] = P_k(x), the Legendre polynomials given
+// by (k+1)*P_{k+1}(x) = (2k+1)*x*P_k(x) - k*P_{k-1}(x).
+func legendreUnivariate(x float64, order int, out []float64) {
+	out[0] = 1
+	if order > 0 {
+		out[1] = x
+	}
+	for k := 1; k < order; k++ {
+		out[k+1] = (float64(2*k+1)*x*out[k] - float64(k)*out[k-1]) / float64(k+1)
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// fittedValueFunction holds the per-period regression fit produced by a
+// backward Lsm pass, so it can be replayed forward on an independent set
+// of paths by LsmBounds.
+type fittedValueFunction struct {
+	basisFunc Basis
+	// coefficients[period] is nil for periods with fewer in-the-money
+	// paths to regress on than the basis has functions, and for the last
+	// period, which has no decision to fit.
+	coefficients []*mat.VecDense
+}
+
+// policy returns the configured ExercisePolicy, defaulting to the
+// original invest-while-NPV-positive decision.
+func (pp *ProjectProcess) policy() ExercisePolicy {
+	if pp.Policy != nil {
+		return pp.Policy
+	}
+	return investPolicy{}
+}
+
+// basis returns the configured Basis, defaulting to the original
+// monomial family sized by PolynomialOrder.
+func (pp *ProjectProcess) basis() Basis {
+	if pp.BasisFunc != nil {
+		return pp.BasisFunc
+	}
+	return NewMonomialBasis(pp.PolynomialOrder)
+}
+
+// basisInputs scales the raw (cost, cash) state down to roughly unit
+// magnitude before it's handed to a Basis. Cost and cash flow are
+// naturally denominated in the project's absolute currency units
+// (TotalExpectedCost and AnnualCashFlow are routinely ~1e8), and every
+// pluggable Basis assumes inputs near order 1: the monomial family's
+// regression matrix is ill-conditioned at that raw scale, and the
+// weighted Laguerre family's exp(-x/2) term underflows to zero outright.
+func (pp *ProjectProcess) basisInputs(cost, cash float64) (x, y float64) {
+	return cost / pp.TotalExpectedCost, cash / pp.AnnualCashFlow
+}
+
+// stepDiscount returns the one-period discount factors for the cash and
+// investment phases over the period starting at index period. If the
+// configured ShortRateModel is a TermStructureDiscounter (e.g.
+// YieldCurve), the factors come directly from its term structure;
+// otherwise they compound the short rate r Simulate realized over that
+// period.
+func (pp *ProjectProcess) stepDiscount(period int, r float64) (cashDiscRate, investDiscRate float64) {
+	if curve, ok := pp.shortRateModel().(TermStructureDiscounter); ok {
+		t0 := float64(period) * pp.TimeStep
+		t1 := t0 + pp.TimeStep
+		cashDiscRate = curve.DiscountFactor(t1) / curve.DiscountFactor(t0)
+		investDiscRate = cashDiscRate * exp(-pp.FailureProb*pp.TimeStep)
+		return cashDiscRate, investDiscRate
+	}
+	cashDiscRate = exp(-r * pp.TimeStep)
+	investDiscRate = exp(-(r + pp.FailureProb) * pp.TimeStep)
+	return cashDiscRate, investDiscRate
+}
+
+// Lsm evaluates the project using the Least Squares Monte Carlo
+// algorithm, regressing the discounted continuation value only on the
+// paths with a live decision (per the configured ExercisePolicy) so the
+// fit isn't diluted by out-of-the-money paths. It returns the full
+// distribution of discounted project values across paths, along with the
+// derived risk and completion statistics.
+func (pp *ProjectProcess) Lsm() *ProjectValuation {
+	values, completionTimes := pp.lsm(nil)
+	return &ProjectValuation{values: values, completionTimes: completionTimes}
+}
+
+// LsmValue evaluates the project exactly like Lsm, returning just the
+// mean project value. It is a thin wrapper kept for callers that only
+// want the point estimate.
+func (pp *ProjectProcess) LsmValue() float64 {
+	return pp.Lsm().Mean()
+}
+
+// LsmStdErr evaluates the project exactly like Lsm, additionally
+// reporting the standard error of the Monte Carlo estimate across paths.
+func (pp *ProjectProcess) LsmStdErr() (mean, stdErr float64) {
+	v := pp.Lsm()
+	return v.Mean(), v.StdErr()
+}
+
+// LsmBounds returns a high-biased and a low-biased Monte Carlo estimate
+// of the project value, not a true (Andersen-Broadie dual) upper and
+// lower bound. highBiased is the standard in-sample Lsm estimate, which
+// benefits from the foresight of fitting and valuing the exercise
+// decision on the same paths. lowBiased replays that fitted
+// continuation-value surface, frozen, on an independent set of paths, so
+// it is instead penalized for following a now sub-optimal, fixed
+// stopping rule out of sample. The true value lies between the two, but
+// neither is a certified bound on it.
+func (pp *ProjectProcess) LsmBounds() (highBiased, lowBiased float64) {
+	var fit fittedValueFunction
+	values, _ := pp.lsm(&fit)
+	highBiased = stat.Mean(values, nil)
+	lowBiased = pp.replay(&fit)
+	return highBiased, lowBiased
+}
+
+// lsm runs the backward value-iteration pass, returning the discounted
+// initial-period value for every path along with the years-to-completion
+// for every path whose investment was carried through to completion
+// (cost hits zero before patent expiry). When capture is non-nil, it is
+// populated with the basis and per-period coefficients fit along the
+// way, for reuse by replay.
+func (pp *ProjectProcess) lsm(capture *fittedValueFunction) (initialValue []float64, completionTimes []float64) {
+	// Calc periods
+	numberOfPeriods := int(float64(pp.PatentLength) / pp.TimeStep)
+	lastPeriod := numberOfPeriods - 1
+
+	// Simulate the cost, cash flow, and short-rate values
+	cashMatrix, costMatrix, rateMatrix := pp.Simulate()
+
+	basisFunc := pp.basis()
+	basisSize := basisFunc.Size()
+	policy := pp.policy()
+
+	if capture != nil {
+		capture.basisFunc = basisFunc
+		capture.coefficients = make([]*mat.VecDense, numberOfPeriods)
+	}
+
+	// valueArray holds the value function iteration matrix
+	valueArray := mat.NewDense(pp.Runs, numberOfPeriods, nil)
+
+	// Set the Terminal Value
+	for run := 0; run < pp.Runs; run++ {
+		// If cost is positive then still investing
+		// and no value in terminal period.
+		if costMatrix.At(run, lastPeriod) == 0 {
+			termVal := pp.TerminalMultiplier * cashMatrix.At(run, lastPeriod)
+			valueArray.Set(run, lastPeriod, termVal)
+		}
+	}
+
+	row := make([]float64, basisSize)
+
+	// Value iteration
+	for period := lastPeriod - 1; period >= 0; period-- {
+
+		// Initialize next periods Value, discounted path-by-path over
+		// the realized short rate r_t rather than a flat scalar, so the
+		// value reflects the project's realized term-structure exposure.
+		nextVal := mat.NewVecDense(pp.Runs, nil)
+		for run := 0; run < pp.Runs; run++ {
+			_, investDiscRate := pp.stepDiscount(period, rateMatrix.At(run, period))
+			nextVal.SetVec(run, investDiscRate*valueArray.At(run, period+1))
+		}
+
+		// Paths with a live decision this period, per the exercise policy.
+		itm := make([]int, 0, pp.Runs)
+		for run := 0; run < pp.Runs; run++ {
+			state := State{Cost: costMatrix.At(run, period), Cash: cashMatrix.At(run, period),
+				TimeStep: pp.TimeStep, Investment: pp.Investment}
+			if policy.InMoney(state) {
+				itm = append(itm, run)
+			}
+		}
+
+		var coefficients *mat.VecDense
+		if len(itm) >= basisSize {
+			// Regression matrix and target restricted to in-the-money paths.
+			basisMatrix := mat.NewDense(len(itm), basisSize, nil)
+			target := mat.NewVecDense(len(itm), nil)
+			for i, run := range itm {
+				x, y := pp.basisInputs(costMatrix.At(run, period), cashMatrix.At(run, period))
+				basisFunc.Eval(x, y, row)
+				basisMatrix.SetRow(i, row)
+				target.SetVec(i, nextVal.AtVec(run))
+			}
+
+			// Solve for regression coefficients with a truncated SVD, which
+			// is genuinely rank-revealing: singular values below rcond
+			// relative to the largest are excluded from the effective
+			// rank, so a nearly singular basis degrades gracefully (a
+			// damped, minimum-norm fit) instead of blowing up -- unlike a
+			// plain QR solve, which only reports ill-conditioning as an
+			// error without changing the answer. coefficients is already
+			// zero-valued, so a genuine factorization failure, or every
+			// singular value falling below rcond, leaves a flat (zero)
+			// continuation value.
+			coefficients = mat.NewVecDense(basisSize, nil)
+			var svd mat.SVD
+			const rcond = 1e-12
+			if ok := svd.Factorize(basisMatrix, mat.SVDThin); ok {
+				if rank := svd.Rank(rcond); rank > 0 {
+					svd.SolveVecTo(coefficients, target, rank)
+				}
+			}
+		}
+
+		if capture != nil {
+			capture.coefficients[period] = coefficients
+		}
+
+		// Determine Value and set in valueArray
+		for run := 0; run < pp.Runs; run++ {
+			state := State{Cost: costMatrix.At(run, period), Cash: cashMatrix.At(run, period),
+				TimeStep: pp.TimeStep, Investment: pp.Investment}
+			if policy.InMoney(state) {
+				if coefficients == nil {
+					continue
+				}
+				x, y := pp.basisInputs(state.Cost, state.Cash)
+				basisFunc.Eval(x, y, row)
+				estVal := floats.Dot(row, coefficients.RawVector().Data)
+				if policy.Continue(state, estVal) {
+					valueArray.Set(run, period, nextVal.AtVec(run)+policy.Payoff(state))
+				}
+			} else {
+				// Post investment sales cash flow
+				cashDiscRate, _ := pp.stepDiscount(period, rateMatrix.At(run, period))
+				valueArray.Set(run, period, cashMatrix.At(run, period)*pp.TimeStep+
+					cashDiscRate*valueArray.At(run, period+1))
+			}
+		}
+	}
+
+	// Convert first month to slice of floats
+	initialValue = make([]float64, pp.Runs)
+	mat.Col(initialValue, 0, valueArray)
+
+	// Discount one last time to initial period, again path-by-path over
+	// the realized rate in the first period.
+	for run := 0; run < pp.Runs; run++ {
+		cashDiscRate, investDiscRate := pp.stepDiscount(0, rateMatrix.At(run, 0))
+		initialValue[run] *= cashDiscRate * investDiscRate
+	}
+
+	// A path's cost process is absorbed at zero once investment
+	// completes, so the first zero-valued period is the completion time.
+	// A path that never hits zero did not complete before the patent
+	// expired. The exercise policy's abandonment decisions are not
+	// consulted: a path the policy abandons still counts as completed if
+	// its simulated cost later reaches zero.
+	completionTimes = make([]float64, 0, pp.Runs)
+	for run := 0; run < pp.Runs; run++ {
+		for period := 0; period < numberOfPeriods; period++ {
+			if costMatrix.At(run, period) == 0 {
+				completionTimes = append(completionTimes, float64(period+1)*pp.TimeStep)
+				break
+			}
+		}
+	}
+
+	return initialValue, completionTimes
+}
+
+// replay applies a frozen fittedValueFunction forward, period by period,
+// on a fresh set of simulated paths drawn from an independent seed,
+// producing an out-of-sample estimate of the project value under the
+// same (now fixed) exercise decisions.
+func (pp *ProjectProcess) replay(fit *fittedValueFunction) float64 {
+	numberOfPeriods := int(float64(pp.PatentLength) / pp.TimeStep)
+	lastPeriod := numberOfPeriods - 1
+
+	outOfSample := *pp
+	outOfSample.Seed = independentSeed(pp.Seed)
+	cashMatrix, costMatrix, rateMatrix := outOfSample.Simulate()
+	policy := pp.policy()
+	basisSize := fit.basisFunc.Size()
+
+	value := make([]float64, pp.Runs)
+	disc := make([]float64, pp.Runs)
+	active := make([]bool, pp.Runs)
+	for run := range active {
+		disc[run] = 1
+		active[run] = true
+	}
+
+	row := make([]float64, basisSize)
+	for period := 0; period < numberOfPeriods; period++ {
+		coefficients := fit.coefficients[period]
+		for run := 0; run < pp.Runs; run++ {
+			if !active[run] {
+				continue
+			}
+			state := State{Cost: costMatrix.At(run, period), Cash: cashMatrix.At(run, period),
+				TimeStep: pp.TimeStep, Investment: pp.Investment}
+
+			// Patent expiry: a completed project pays its terminal
+			// multiple, an unfinished one pays nothing further.
+			if period == lastPeriod {
+				if !policy.InMoney(state) {
+					value[run] += disc[run] * pp.TerminalMultiplier * state.Cash
+				}
+				active[run] = false
+				continue
+			}
+
+			cashDiscRate, investDiscRate := pp.stepDiscount(period, rateMatrix.At(run, period))
+
+			if !policy.InMoney(state) {
+				value[run] += disc[run] * state.Cash * pp.TimeStep
+				disc[run] *= cashDiscRate
+				continue
+			}
+
+			if coefficients == nil {
+				active[run] = false
+				continue
+			}
+
+			x, y := pp.basisInputs(state.Cost, state.Cash)
+			fit.basisFunc.Eval(x, y, row)
+			estVal := floats.Dot(row, coefficients.RawVector().Data)
+			if policy.Continue(state, estVal) {
+				value[run] += disc[run] * policy.Payoff(state)
+				disc[run] *= investDiscRate
+			} else {
+				active[run] = false
+			}
+		}
+	}
+
+	// Discount one last time to the initial period, as lsm does.
+	for run := range value {
+		cashDiscRate, investDiscRate := pp.stepDiscount(0, rateMatrix.At(run, 0))
+		value[run] *= cashDiscRate * investDiscRate
+	}
+
+	return stat.Mean(value, nil)
+}
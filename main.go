@@ -6,10 +6,6 @@ import (
 	"math"
 
 	"github.com/leekchan/accounting"
-	"golang.org/x/exp/rand"
-	"gonum.org/v1/gonum/floats"
-	"gonum.org/v1/gonum/mat"
-	"gonum.org/v1/gonum/stat"
 )
 
 // CashProcess contains the assumptions of the cash flow simulation
@@ -35,6 +31,23 @@ type Simulation struct {
 	PatentLength    int
 	Runs            int
 	PolynomialOrder int
+	// BasisFunc selects the basis used to approximate the continuation
+	// value in Lsm. If nil, it defaults to NewMonomialBasis(PolynomialOrder).
+	BasisFunc Basis
+	// Policy selects the exercise decision applied at each regression step
+	// of Lsm. If nil, it defaults to the original invest-while-NPV-positive
+	// decision.
+	Policy ExercisePolicy
+	// Seed is the master seed for the per-path random number streams used
+	// by Simulate. Runs with the same Seed produce bitwise-identical
+	// results regardless of Parallelism.
+	Seed uint64
+	// Parallelism is the number of goroutines Simulate spreads paths
+	// across. 0 selects runtime.NumCPU().
+	Parallelism int
+	// Sampler selects the variance-reduction technique used to draw each
+	// path's random innovations. Zero value is SamplerPseudo.
+	Sampler Sampler
 }
 
 // ProjectProcess contains the correlated structures of the cost
@@ -46,12 +59,28 @@ type ProjectProcess struct {
 	Correlation  float64
 	RiskFreeRate float64
 	Simulation
+	// ShortRate selects the stochastic process for the short rate. If
+	// nil, it defaults to ConstantRate{Rate: RiskFreeRate}, reproducing
+	// the original flat-discounting behavior.
+	ShortRate ShortRateModel
+	// CostRateCorrelation and CashRateCorrelation correlate the short
+	// rate factor with the cost and cash processes, extending
+	// Correlation's 2x2 correlation matrix to a 3x3 Cholesky
+	// decomposition.
+	CostRateCorrelation float64
+	CashRateCorrelation float64
 }
 
-func main() {
-	// Set random seed
-	rand.Seed(355)
+// shortRateModel returns the configured ShortRateModel, defaulting to a
+// constant rate equal to RiskFreeRate.
+func (pp *ProjectProcess) shortRateModel() ShortRateModel {
+	if pp.ShortRate != nil {
+		return pp.ShortRate
+	}
+	return ConstantRate{Rate: pp.RiskFreeRate}
+}
 
+func main() {
 	// Initialize project components
 	cashProcess := CashProcess{
 		AnnualCashFlow:     20e6,
@@ -70,7 +99,9 @@ func main() {
 		TimeStep:        0.25,
 		PatentLength:    20,
 		Runs:            200_000,
-		PolynomialOrder: 9,
+		PolynomialOrder: 2,
+		BasisFunc:       NewMonomialBasis(2),
+		Seed:            355,
 	}
 
 	project := ProjectProcess{
@@ -82,150 +113,15 @@ func main() {
 	}
 
 	// Estimate Project Value
-	projectValue := project.Lsm()
+	valuation := project.Lsm()
 
 	// Print currency
 	ac := accounting.Accounting{Symbol: "$", Precision: 2}
-	fmt.Println("The Project Value:", ac.FormatMoney(projectValue))
-}
-
-// Simulate returns the correlated cash and cost processes.
-func (pp *ProjectProcess) Simulate() (*mat.Dense, *mat.Dense) {
-
-	// Set number of periods
-	numberOfPeriods := int(float64(pp.PatentLength) / pp.TimeStep)
-
-	// Risk adjusted cash flow drift rate
-	adjCashDrift := pp.CashProcess.Drift - pp.RiskPremium
-
-	// Matrices to hold the simulated cash and cost values
-	netCash := mat.NewDense(pp.Runs, numberOfPeriods, nil)
-	cost := mat.NewDense(pp.Runs, numberOfPeriods, nil)
-
-	// stochastic simulation of the investment costs and cash flows
-	for run := 0; run < pp.Runs; run++ {
-		for period := 0; period < numberOfPeriods; period++ {
-
-			// correlate random variables
-			costEps := rand.NormFloat64()
-			cashEps := pp.Correlation*costEps + sqrt(1-sqr(pp.Correlation))*rand.NormFloat64()
-
-			// cash flow simulation
-			prevCash := pp.AnnualCashFlow
-			if period != 0 {
-				prevCash = netCash.At(run, period-1)
-			}
-			nextCash := prevCash * exp((adjCashDrift-0.5*sqr(pp.CashProcess.Volatility))*pp.TimeStep+
-				pp.CashProcess.Volatility*sqrt(pp.TimeStep)*cashEps)
-			netCash.Set(run, period, nextCash)
-
-			// cost simulation
-			prevCost := pp.TotalExpectedCost
-			if period != 0 {
-				prevCost = cost.At(run, period-1)
-			}
-
-			// Only update costs if not zero
-			nextCost := 0.0
-			if prevCost != 0 {
-				nextCost = prevCost - pp.Investment*pp.TimeStep +
-					pp.CostProcess.Volatility*sqrt(pp.Investment*prevCost*pp.TimeStep)*costEps
-				if nextCost < 0 {
-					nextCost = 0
-				}
-			}
-			cost.Set(run, period, nextCost)
-
-		}
-
-	}
-
-	return netCash, cost
-}
-
-// Lsm evaluates the project using the Least Squares Monte Carlo algorithm
-func (pp *ProjectProcess) Lsm() float64 {
-	// Calc periods
-	numberOfPeriods := int(float64(pp.PatentLength) / pp.TimeStep)
-	lastPeriod := numberOfPeriods - 1
-
-	// Simulate the cost and cash flow values
-	cashMatrix, costMatrix := pp.Simulate()
-
-	// valueArray holds the value function iteration matrix
-	valueArray := mat.NewDense(pp.Runs, numberOfPeriods, nil)
-
-	// Set the Terminal Value
-	for run := 0; run < pp.Runs; run++ {
-		// If cost is positive then still investing
-		// and no value in terminal period.
-		if costMatrix.At(run, lastPeriod) == 0 {
-			termVal := pp.TerminalMultiplier * cashMatrix.At(run, lastPeriod)
-			valueArray.Set(run, lastPeriod, termVal)
-		}
-
-		// if costMatrix.At(run, lastPeriod) != 0 {
-		// 	fmt.Println(valueArray.At(run, lastPeriod))
-		// }
-	}
-
-	// Discount rates depending on the phase of the project
-	cashDiscRate := exp(-1 * pp.RiskFreeRate * pp.TimeStep)
-	investDiscRate := exp(-1 * (pp.RiskFreeRate + pp.FailureProb) * pp.TimeStep)
-
-	// Value iteration
-	for period := lastPeriod - 1; period >= 0; period-- {
-
-		// Initialize next periods Value
-		nextVal := mat.NewVecDense(pp.Runs, nil)
-
-		// Discount next period's value to serve as the dependent variable of the regression
-		nextVal.ScaleVec(investDiscRate, valueArray.ColView(period+1))
-
-		// Initialize basis matrix for regression
-		basisMatrix := mat.NewDense(pp.Runs, 9, nil)
-
-		// Set basis matrix rows for the regression
-		for run := 0; run < pp.Runs; run++ {
-			basisMatrix.SetRow(run, basis(costMatrix.At(run, period), cashMatrix.At(run, period)))
-		}
-
-		// Solve for regression coefficients
-		coefficients := mat.NewVecDense(9, nil)
-		coefficients.SolveVec(basisMatrix, nextVal)
-
-		// Estimate continuation value of investment
-		estVal := mat.NewVecDense(pp.Runs, nil)
-		estVal.MulVec(basisMatrix, coefficients)
-
-		// Determine Value and set in valueArray
-		for run := 0; run < pp.Runs; run++ {
-			// Investing Value
-			if costMatrix.At(run, period) != 0 {
-				investVal := estVal.AtVec(run) - pp.Investment*pp.TimeStep
-				// Only invest if project value is positive after investment
-				if investVal > 0 {
-					valueArray.Set(run, period, nextVal.AtVec(run)-pp.Investment*pp.TimeStep)
-				}
-			} else {
-				// Post investment sales cash flow
-				valueArray.Set(run, period, cashMatrix.At(run, period)*pp.TimeStep+
-					cashDiscRate*valueArray.At(run, period+1))
-			}
-		}
-	}
-
-	// Convert first month to slice of floats
-	initialValue := make([]float64, pp.Runs)
-	mat.Col(initialValue, 0, valueArray)
-
-	// Discount one last time to initial period
-	floats.ScaleTo(initialValue, cashDiscRate*investDiscRate, initialValue)
-
-	// Average discounted initial period across all runs
-	retVal := stat.Mean(initialValue, nil)
-
-	return retVal
+	fmt.Println("The Project Value:", ac.FormatMoney(valuation.Mean()))
+	fmt.Printf("Std Error: %s\n", ac.FormatMoney(valuation.StdErr()))
+	fmt.Printf("5%% CVaR: %s\n", ac.FormatMoney(valuation.CVaR(0.05)))
+	fmt.Printf("Probability of Abandonment: %.1f%%\n", valuation.ProbAbandoned()*100)
+	fmt.Printf("Expected Time to Completion (success): %.2f years\n", valuation.ExpectedTimeToCompletion())
 }
 
 // helper functions
@@ -233,11 +129,6 @@ func (pp *ProjectProcess) Lsm() float64 {
 // square the input
 func sqr(x float64) float64 { return x * x }
 
-// polynomial basis to approximate the value function
-func basis(x, y float64) []float64 {
-	return []float64{1, x, y, x * y, sqr(x), sqr(y), sqr(x) * y, x * sqr(y), sqr(x * y)}
-}
-
 // local function aliases
 var exp = math.Exp
 var sqrt = math.Sqrt
@@ -0,0 +1,45 @@
+package main
+
+// State is the per-path, per-period information available to an
+// ExercisePolicy when it decides whether a path continues.
+type State struct {
+	// Cost is the remaining cost-to-completion; zero once investment is done.
+	Cost float64
+	// Cash is the annualized net cash flow this period; the period's
+	// realized cash flow is Cash*TimeStep.
+	Cash float64
+	// TimeStep is the simulation's period length, in years.
+	TimeStep float64
+	// Investment is the annualized investment rate while Cost > 0.
+	Investment float64
+}
+
+// ExercisePolicy decides, at each regression step of Lsm, whether a path
+// still has a decision to make, what it costs to keep the path alive, and
+// whether the fitted continuation value justifies doing so. Implementing
+// this lets callers plug in abandonment options, expansion/contraction
+// (compound real options), or staged R&D decisions without editing Lsm.
+type ExercisePolicy interface {
+	// InMoney reports whether state has a live decision this period, and
+	// therefore belongs in the cross-sectional regression.
+	InMoney(state State) bool
+	// Payoff returns the cash flow realized by continuing at state, e.g.
+	// the cost of the next period's investment.
+	Payoff(state State) float64
+	// Continue reports whether, given the fitted continuation value
+	// contValue, the path should continue rather than be abandoned.
+	Continue(state State, contValue float64) bool
+}
+
+// investPolicy reproduces the project's original hardcoded decision:
+// keep investing while the estimated post-investment value still exceeds
+// the cost of the next period's investment.
+type investPolicy struct{}
+
+func (investPolicy) InMoney(state State) bool { return state.Cost > 0 }
+
+func (investPolicy) Payoff(state State) float64 { return -state.Investment * state.TimeStep }
+
+func (investPolicy) Continue(state State, contValue float64) bool {
+	return contValue-state.Investment*state.TimeStep > 0
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Sampler selects the variance-reduction technique Simulate uses to draw
+// the period-by-period standard normal innovations for each path.
+type Sampler int
+
+const (
+	// SamplerPseudo draws independent pseudo-random normals per path
+	// (the original behavior).
+	SamplerPseudo Sampler = iota
+	// SamplerAntithetic pairs each path with a mirrored path driven by
+	// the negation of its draws. Runs must be even, or Simulate panics.
+	SamplerAntithetic
+	// SamplerSobol draws from a digitally-shifted Sobol low-discrepancy
+	// sequence instead of a pseudo-random stream, which substantially
+	// reduces the standard error of the averaged LSM estimate for a
+	// fixed path count.
+	SamplerSobol
+)
+
+// pathDraws produces the period-by-period independent standard normal
+// innovations for a single simulated path. Simulate correlates them into
+// the cost, cash-flow, and short-rate processes via a 3x3 Cholesky
+// decomposition; the third draw is always produced so a path's first two
+// draws don't depend on whether a ShortRateModel is configured.
+type pathDraws interface {
+	normals(period int) (z0, z1, z2 float64)
+}
+
+// pseudoDraws draws independent pseudo-random normals from a dedicated
+// per-path RNG stream.
+type pseudoDraws struct {
+	rng *rand.Rand
+}
+
+func (d pseudoDraws) normals(period int) (float64, float64, float64) {
+	return d.rng.NormFloat64(), d.rng.NormFloat64(), d.rng.NormFloat64()
+}
+
+// antitheticDraws mirrors another path's draws by negating them, so the
+// pair (run, run+half) explore opposite tails of the distribution.
+type antitheticDraws struct {
+	base pathDraws
+}
+
+func (d antitheticDraws) normals(period int) (float64, float64, float64) {
+	z0, z1, z2 := d.base.normals(period)
+	return -z0, -z1, -z2
+}
+
+// sobolDimension holds one dimension's 32 direction numbers, one per bit
+// of the path index, scaled to 32-bit fixed point (see sobolPoint).
+type sobolDimension [32]uint32
+
+// sobolDraws draws normals from a Sobol low-discrepancy sequence: each
+// path is one index (run) of the sequence, and every (period, factor)
+// pair gets its own Sobol dimension, built from its own primitive
+// polynomial over GF(2) (see newSobolDimensions). So, unlike reusing a
+// single 1-D sequence with a per-dimension shift, the joint draws across
+// periods and factors are not rank-correlated. Each dimension is also
+// rotated by an independent digital shift (a Cranley-Patterson rotation)
+// derived from the master Seed, so a given Seed still reproduces the
+// same sequence without every path starting at the sequence's shared
+// origin.
+type sobolDraws struct {
+	run        int
+	dimensions []sobolDimension // dimensions[3*period], dimensions[3*period+1], dimensions[3*period+2]
+	shift      []uint32         // shift[3*period], shift[3*period+1], shift[3*period+2]
+}
+
+// newSobolDimensions derives `dims` genuinely distinct Sobol dimensions.
+// The first is the standard base-2 van der Corput sequence; each later
+// dimension is generated from its own primitive polynomial over GF(2),
+// taken in order from primitivePolynomials, so no two dimensions are
+// shifted copies of the same 1-D sequence.
+func newSobolDimensions(dims int) []sobolDimension {
+	dimensions := make([]sobolDimension, dims)
+	if dims == 0 {
+		return dimensions
+	}
+	for bit := 0; bit < 32; bit++ {
+		dimensions[0][bit] = uint32(1) << uint(31-bit)
+	}
+	for d, poly := range primitivePolynomials(dims - 1) {
+		dimensions[d+1] = sobolDirectionNumbers(poly)
+	}
+	return dimensions
+}
+
+// newSobolShift derives the per-dimension digital shift for a
+// `dims`-dimensional Sobol sequence from the master Simulation.Seed, so a
+// given Seed always reproduces the same rotated sequence.
+func newSobolShift(seed uint64, dims int) []uint32 {
+	shift := make([]uint32, dims)
+	for d := 0; d < dims; d++ {
+		shift[d] = uint32(splitMix64(seed + uint64(d)*goldenGamma64))
+	}
+	return shift
+}
+
+// sobolPoint returns the index'th point of the Sobol dimension described
+// by dim, computed directly as the XOR-fold of its direction numbers over
+// the set bits of index (Sobol's original construction), then rotated by
+// mask and mapped into (0, 1).
+func sobolPoint(index int, dim sobolDimension, mask uint32) float64 {
+	var bits uint32
+	for bit := 0; bit < 32 && index != 0; bit++ {
+		if index&1 != 0 {
+			bits ^= dim[bit]
+		}
+		index >>= 1
+	}
+	bits ^= mask
+	// Keep away from the 0/1 boundary, where the normal quantile diverges.
+	const eps = 1.0 / (1 << 33)
+	u := float64(bits)/float64(1<<32) + eps
+	if u >= 1 {
+		u = 1 - eps
+	}
+	return u
+}
+
+func (d sobolDraws) normals(period int) (float64, float64, float64) {
+	u0 := sobolPoint(d.run, d.dimensions[3*period], d.shift[3*period])
+	u1 := sobolPoint(d.run, d.dimensions[3*period+1], d.shift[3*period+1])
+	u2 := sobolPoint(d.run, d.dimensions[3*period+2], d.shift[3*period+2])
+	return distuv.UnitNormal.Quantile(u0), distuv.UnitNormal.Quantile(u1), distuv.UnitNormal.Quantile(u2)
+}
@@ -0,0 +1,122 @@
+package main
+
+import "sort"
+
+// ShortRateModel describes the stochastic process driving the short rate
+// r_t that Simulate evolves as a third correlated factor alongside the
+// cost and cash-flow processes, and that Lsm discounts each path by.
+type ShortRateModel interface {
+	// Initial returns the starting short rate r_0.
+	Initial() float64
+	// Drift returns the risk-neutral drift of r at the given level.
+	Drift(r float64) float64
+	// Diffusion returns the instantaneous volatility of r at the given level.
+	Diffusion(r float64) float64
+}
+
+// TermStructureDiscounter is an optional extension of ShortRateModel for
+// models, like YieldCurve, whose discount factor to an arbitrary time is
+// known directly rather than only implied by compounding a simulated
+// short-rate path. Lsm prefers DiscountFactor over path-by-path
+// compounding when the configured ShortRateModel implements it, so a
+// non-flat term structure actually changes the discounted value instead
+// of collapsing to the flat rate Initial() returns.
+type TermStructureDiscounter interface {
+	ShortRateModel
+	// DiscountFactor returns the discount factor for a cash flow
+	// received at time t (in years from time zero).
+	DiscountFactor(t float64) float64
+}
+
+// ConstantRate is a degenerate ShortRateModel with no stochastic
+// component, reproducing the model's original flat RiskFreeRate.
+type ConstantRate struct {
+	Rate float64
+}
+
+func (m ConstantRate) Initial() float64            { return m.Rate }
+func (m ConstantRate) Drift(r float64) float64     { return 0 }
+func (m ConstantRate) Diffusion(r float64) float64 { return 0 }
+
+// Vasicek is the Vasicek short-rate model, dr = A*(B-r)*dt + Sigma*dW,
+// mean-reverting to B at speed A.
+type Vasicek struct {
+	A, B, Sigma, R0 float64
+}
+
+func (m Vasicek) Initial() float64            { return m.R0 }
+func (m Vasicek) Drift(r float64) float64     { return m.A * (m.B - r) }
+func (m Vasicek) Diffusion(r float64) float64 { return m.Sigma }
+
+// CIR is the Cox-Ingersoll-Ross short-rate model,
+// dr = A*(B-r)*dt + Sigma*sqrt(r)*dW, which keeps r non-negative.
+type CIR struct {
+	A, B, Sigma, R0 float64
+}
+
+func (m CIR) Initial() float64        { return m.R0 }
+func (m CIR) Drift(r float64) float64 { return m.A * (m.B - r) }
+func (m CIR) Diffusion(r float64) float64 {
+	if r <= 0 {
+		return 0
+	}
+	return m.Sigma * sqrt(r)
+}
+
+// YieldCurve is a deterministic, non-flat discount curve bootstrapped
+// from zero rates observed at a set of tenors, linearly interpolated
+// (and flat-extrapolated) in between.
+type YieldCurve struct {
+	tenor []float64
+	zero  []float64
+}
+
+// NewYieldCurve bootstraps a YieldCurve from tenor/zero-rate pairs.
+func NewYieldCurve(points []struct{ Tenor, Zero float64 }) YieldCurve {
+	sorted := append([]struct{ Tenor, Zero float64 }(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Tenor < sorted[j].Tenor })
+
+	yc := YieldCurve{tenor: make([]float64, len(sorted)), zero: make([]float64, len(sorted))}
+	for i, p := range sorted {
+		yc.tenor[i] = p.Tenor
+		yc.zero[i] = p.Zero
+	}
+	return yc
+}
+
+// Zero returns the zero rate for tenor t.
+func (yc YieldCurve) Zero(t float64) float64 {
+	n := len(yc.tenor)
+	if n == 0 {
+		return 0
+	}
+	if t <= yc.tenor[0] {
+		return yc.zero[0]
+	}
+	if t >= yc.tenor[n-1] {
+		return yc.zero[n-1]
+	}
+	for i := 1; i < n; i++ {
+		if t <= yc.tenor[i] {
+			t0, t1 := yc.tenor[i-1], yc.tenor[i]
+			z0, z1 := yc.zero[i-1], yc.zero[i]
+			return z0 + (t-t0)/(t1-t0)*(z1-z0)
+		}
+	}
+	return yc.zero[n-1]
+}
+
+// DiscountFactor returns the deterministic discount factor exp(-Zero(t)*t)
+// for a cash flow received at time t.
+func (yc YieldCurve) DiscountFactor(t float64) float64 {
+	return exp(-yc.Zero(t) * t)
+}
+
+// Initial, Drift, and Diffusion let a YieldCurve double as a
+// (deterministic) ShortRateModel, using its instantaneous zero rate. Lsm
+// discounts through DiscountFactor instead (see TermStructureDiscounter),
+// so these only matter for the rate Simulate records alongside the cost
+// and cash-flow paths.
+func (yc YieldCurve) Initial() float64            { return yc.Zero(0) }
+func (yc YieldCurve) Drift(r float64) float64     { return 0 }
+func (yc YieldCurve) Diffusion(r float64) float64 { return 0 }
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// goldenGamma64 is SplitMix64's fixed increment (the odd part of the
+// golden ratio in 64-bit fixed point), used to space out substream seeds.
+const goldenGamma64 = 0x9E3779B97F4A7C15
+
+// splitMix64 is the SplitMix64 output mixing function: it takes an
+// arbitrary 64-bit input and returns a well-distributed 64-bit output.
+func splitMix64(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27)) * 0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
+
+// pathSeed derives the random seed for a single simulated path from the
+// master Simulation.Seed and the path's run index, via a SplitMix64 jump.
+// Because it depends only on (seed, run), a path produces the same draws
+// no matter how the runs are partitioned across goroutines, which is what
+// makes Simulate's output invariant to Parallelism.
+func pathSeed(seed uint64, run int) uint64 {
+	return splitMix64(seed + uint64(run)*goldenGamma64)
+}
+
+// independentSeed derives a new master seed from seed via a SplitMix64
+// jump, for a Simulate call that must draw paths independent of the
+// paths seed itself produces (see replay in lsm.go).
+func independentSeed(seed uint64) uint64 {
+	return splitMix64(seed ^ goldenGamma64)
+}
+
+// pathDraws returns the draw source for a single path, per the
+// configured Sampler. sobol is the zero value and unused unless Sampler
+// is SamplerSobol.
+func (pp *ProjectProcess) pathDraws(run int, sobol sobolSetup) pathDraws {
+	switch pp.Sampler {
+	case SamplerAntithetic:
+		half := pp.Runs / 2
+		pair := run
+		if run >= half {
+			pair = run - half
+		}
+		base := pseudoDraws{rng: rand.New(rand.NewSource(pathSeed(pp.Seed, pair)))}
+		if run < half {
+			return base
+		}
+		return antitheticDraws{base: base}
+	case SamplerSobol:
+		return sobolDraws{run: run, dimensions: sobol.dimensions, shift: sobol.shift}
+	default:
+		return pseudoDraws{rng: rand.New(rand.NewSource(pathSeed(pp.Seed, run)))}
+	}
+}
+
+// sobolSetup bundles the Sobol dimensions and digital shift shared by
+// every path in a SamplerSobol simulation, computed once up front.
+type sobolSetup struct {
+	dimensions []sobolDimension
+	shift      []uint32
+}
+
+// Simulate returns the correlated cash, cost, and short-rate processes.
+// The short rate is the third factor in a 3x3 Cholesky correlation
+// alongside cost and cash, evolved via the configured ShortRateModel.
+func (pp *ProjectProcess) Simulate() (netCash, cost, rate *mat.Dense) {
+	if pp.Sampler == SamplerAntithetic && pp.Runs%2 != 0 {
+		panic("realoptions: SamplerAntithetic requires an even Runs")
+	}
+
+	// Set number of periods
+	numberOfPeriods := int(float64(pp.PatentLength) / pp.TimeStep)
+
+	// Risk adjusted cash flow drift rate
+	adjCashDrift := pp.CashProcess.Drift - pp.RiskPremium
+
+	// Matrices to hold the simulated cash, cost, and short-rate values
+	netCash = mat.NewDense(pp.Runs, numberOfPeriods, nil)
+	cost = mat.NewDense(pp.Runs, numberOfPeriods, nil)
+	rate = mat.NewDense(pp.Runs, numberOfPeriods, nil)
+	rateModel := pp.shortRateModel()
+
+	// Cholesky factor of the 3x3 correlation matrix between the cost,
+	// cash, and rate factors: [1 rhoCC rhoCR; rhoCC 1 rhoXR; rhoCR rhoXR 1].
+	rhoCC := pp.Correlation
+	rhoCR := pp.CostRateCorrelation
+	rhoXR := pp.CashRateCorrelation
+	l21 := rhoCC
+	l22 := sqrt(1 - sqr(rhoCC))
+	l31 := rhoCR
+	l32 := (rhoXR - rhoCC*rhoCR) / l22
+	l33 := sqrt(1 - sqr(l31) - sqr(l32))
+
+	var sobol sobolSetup
+	if pp.Sampler == SamplerSobol {
+		dims := 3 * numberOfPeriods
+		sobol = sobolSetup{dimensions: newSobolDimensions(dims), shift: newSobolShift(pp.Seed, dims)}
+	}
+
+	workers := pp.Parallelism
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > pp.Runs {
+		workers = pp.Runs
+	}
+
+	// Spread paths across workers in disjoint, contiguous row ranges.
+	// mat.Dense.Set on non-overlapping rows doesn't race, and each path's
+	// own substream (see pathSeed) makes the result independent of how
+	// the ranges are split.
+	var wg sync.WaitGroup
+	chunk := (pp.Runs + workers - 1) / workers
+	for start := 0; start < pp.Runs; start += chunk {
+		end := start + chunk
+		if end > pp.Runs {
+			end = pp.Runs
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for run := start; run < end; run++ {
+				draws := pp.pathDraws(run, sobol)
+				for period := 0; period < numberOfPeriods; period++ {
+
+					// correlate random variables via the 3x3 Cholesky factor
+					z0, z1, z2 := draws.normals(period)
+					costEps := z0
+					cashEps := l21*z0 + l22*z1
+					rateEps := l31*z0 + l32*z1 + l33*z2
+
+					// short rate simulation
+					prevRate := rateModel.Initial()
+					if period != 0 {
+						prevRate = rate.At(run, period-1)
+					}
+					nextRate := prevRate + rateModel.Drift(prevRate)*pp.TimeStep +
+						rateModel.Diffusion(prevRate)*sqrt(pp.TimeStep)*rateEps
+					rate.Set(run, period, nextRate)
+
+					// cash flow simulation
+					prevCash := pp.AnnualCashFlow
+					if period != 0 {
+						prevCash = netCash.At(run, period-1)
+					}
+					nextCash := prevCash * exp((adjCashDrift-0.5*sqr(pp.CashProcess.Volatility))*pp.TimeStep+
+						pp.CashProcess.Volatility*sqrt(pp.TimeStep)*cashEps)
+					netCash.Set(run, period, nextCash)
+
+					// cost simulation
+					prevCost := pp.TotalExpectedCost
+					if period != 0 {
+						prevCost = cost.At(run, period-1)
+					}
+
+					// Only update costs if not zero
+					nextCost := 0.0
+					if prevCost != 0 {
+						nextCost = prevCost - pp.Investment*pp.TimeStep +
+							pp.CostProcess.Volatility*sqrt(pp.Investment*prevCost*pp.TimeStep)*costEps
+						if nextCost < 0 {
+							nextCost = 0
+						}
+					}
+					cost.Set(run, period, nextCost)
+
+				}
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	return netCash, cost, rate
+}
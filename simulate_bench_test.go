@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+// benchProject returns a small ProjectProcess suitable for benchmarking
+// Simulate; Runs and PatentLength are kept modest so sub-benchmarks
+// finish quickly even with Parallelism forced to 1.
+func benchProject() ProjectProcess {
+	return ProjectProcess{
+		CashProcess: CashProcess{
+			AnnualCashFlow: 20e6,
+			Drift:          0.02,
+			Volatility:     0.35,
+			RiskPremium:    0.036,
+		},
+		CostProcess: CostProcess{
+			Investment:        10e6,
+			TotalExpectedCost: 100e6,
+			Volatility:        0.5,
+			FailureProb:       0.06931,
+		},
+		Correlation:  -0.1,
+		RiskFreeRate: 0.05,
+		Simulation: Simulation{
+			TimeStep:     0.25,
+			PatentLength: 20,
+			Runs:         20_000,
+			Seed:         355,
+		},
+	}
+}
+
+// BenchmarkSimulateParallelism runs Simulate at increasing Parallelism to
+// demonstrate that splitting paths across more workers scales close to
+// linearly, since each path's random draws come from its own substream.
+func BenchmarkSimulateParallelism(b *testing.B) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(strconv.Itoa(workers), func(b *testing.B) {
+			pp := benchProject()
+			pp.Parallelism = workers
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				pp.Simulate()
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+// This file builds the per-dimension recurrences newSobolDimensions needs:
+// a stream of primitive polynomials over GF(2), one per Sobol dimension
+// beyond the first, and the direction-number recurrence each polynomial
+// drives. Polynomials are found by brute-force search rather than from an
+// embedded table, so the sequence isn't capped at some hardcoded
+// dimension count.
+
+// gf2Polynomial is a polynomial over GF(2), represented as a bitmask
+// whose bit i is the coefficient of x^i.
+type gf2Polynomial uint32
+
+// degree returns the highest power with a nonzero coefficient, or -1 for
+// the zero polynomial.
+func (p gf2Polynomial) degree() int {
+	d := -1
+	for b := p; b != 0; b >>= 1 {
+		d++
+	}
+	return d
+}
+
+// mul returns p*q over GF(2) (no reduction).
+func (p gf2Polynomial) mul(q gf2Polynomial) gf2Polynomial {
+	var r gf2Polynomial
+	for ; q != 0; q >>= 1 {
+		if q&1 != 0 {
+			r ^= p
+		}
+		p <<= 1
+	}
+	return r
+}
+
+// mod returns p reduced modulo m (m != 0).
+func (p gf2Polynomial) mod(m gf2Polynomial) gf2Polynomial {
+	dm := m.degree()
+	for {
+		dp := p.degree()
+		if p == 0 || dp < dm {
+			return p
+		}
+		p ^= m << uint(dp-dm)
+	}
+}
+
+// gcd returns the monic GCD of p and q over GF(2).
+func (p gf2Polynomial) gcd(q gf2Polynomial) gf2Polynomial {
+	for q != 0 {
+		p, q = q, p.mod(q)
+	}
+	return p
+}
+
+// powMod returns p^e mod m.
+func (p gf2Polynomial) powMod(e uint64, m gf2Polynomial) gf2Polynomial {
+	result := gf2Polynomial(1)
+	base := p.mod(m)
+	for e > 0 {
+		if e&1 != 0 {
+			result = result.mul(base).mod(m)
+		}
+		base = base.mul(base).mod(m)
+		e >>= 1
+	}
+	return result
+}
+
+// primeFactors returns the distinct prime factors of n, by trial
+// division. n is always small here (a polynomial degree, or 2^degree-1
+// for degree up to a few dozen), so this is cheap.
+func primeFactors(n uint64) []uint64 {
+	var factors []uint64
+	for p := uint64(2); p*p <= n; p++ {
+		if n%p == 0 {
+			factors = append(factors, p)
+			for n%p == 0 {
+				n /= p
+			}
+		}
+	}
+	if n > 1 {
+		factors = append(factors, n)
+	}
+	return factors
+}
+
+// isPrimitive reports whether poly, a degree-n polynomial over GF(2), is
+// primitive: irreducible (via Rabin's test), with x a generator of the
+// multiplicative group of GF(2^n)/poly.
+func isPrimitive(n int, poly gf2Polynomial) bool {
+	const x = gf2Polynomial(2)
+
+	// Rabin's irreducibility test: x^(2^n) == x (mod poly), and for every
+	// prime p dividing n, gcd(x^(2^(n/p)) - x, poly) == 1.
+	if x.powMod(uint64(1)<<uint(n), poly) != x.mod(poly) {
+		return false
+	}
+	for _, p := range primeFactors(uint64(n)) {
+		diff := x.powMod(uint64(1)<<uint(n/int(p)), poly) ^ x.mod(poly)
+		if poly.gcd(diff) != 1 {
+			return false
+		}
+	}
+
+	// Primitivity: the order of x modulo poly is exactly 2^n - 1.
+	order := uint64(1)<<uint(n) - 1
+	if x.powMod(order, poly) != 1 {
+		return false
+	}
+	for _, p := range primeFactors(order) {
+		if x.powMod(order/p, poly) == 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// primitivePolynomial is a polynomial of the given degree; poly is its full
+// bitmask, whose leading x^degree term and constant term are always 1,
+// since a primitive polynomial must have both.
+type primitivePolynomial struct {
+	degree int
+	poly   gf2Polynomial
+}
+
+// primitivePolynomials returns the first n primitive polynomials over
+// GF(2), in order of increasing degree (then increasing interior
+// coefficients), found by brute-force search. Distinct primitive
+// polynomials drive genuinely distinct Sobol dimensions.
+func primitivePolynomials(n int) []primitivePolynomial {
+	if n <= 0 {
+		return nil
+	}
+	polys := make([]primitivePolynomial, 0, n)
+	for degree := 1; len(polys) < n; degree++ {
+		for interior := gf2Polynomial(0); interior < gf2Polynomial(1)<<uint(degree-1); interior++ {
+			poly := gf2Polynomial(1)<<uint(degree) | interior<<1 | 1
+			if isPrimitive(degree, poly) {
+				polys = append(polys, primitivePolynomial{degree: degree, poly: poly})
+				if len(polys) == n {
+					break
+				}
+			}
+		}
+	}
+	return polys
+}
+
+// sobolDirectionNumbers computes the 32 direction numbers (one per bit of
+// the path index, scaled to 32-bit fixed point) for the Sobol dimension
+// generated by p, following the standard Sobol/Bratley-Fox recurrence.
+// Every initial direction number is taken to be 1, the minimal choice
+// satisfying the required m_i odd, m_i < 2^i -- enough for p's recurrence
+// to produce a genuinely distinct low-discrepancy dimension, without
+// needing the published, quality-tuned initial numbers of a specific
+// dimension table.
+func sobolDirectionNumbers(p primitivePolynomial) sobolDimension {
+	s := p.degree
+	var m [33]uint32 // 1-indexed: m[1..32]
+	for i := 1; i <= s; i++ {
+		m[i] = 1
+	}
+	for i := s + 1; i <= 32; i++ {
+		v := m[i-s] ^ (m[i-s] << uint(s))
+		for k := 1; k < s; k++ {
+			// a_k is the coefficient of x^(s-k).
+			if p.poly&(1<<uint(s-k)) != 0 {
+				v ^= uint32(1) << uint(k) * m[i-k]
+			}
+		}
+		m[i] = v
+	}
+	var dim sobolDimension
+	for i := 1; i <= 32; i++ {
+		dim[i-1] = m[i] << uint(32-i)
+	}
+	return dim
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/stat"
+)
+
+// ProjectValuation holds the full distribution of discounted
+// initial-period project values produced by Lsm, plus the risk metrics
+// and completion statistics derived from it.
+type ProjectValuation struct {
+	values []float64
+	// completionTimes holds the years-to-completion for each path whose
+	// simulated cost-to-completion reached zero within the patent life.
+	completionTimes []float64
+}
+
+// Mean returns the Monte Carlo estimate of the project value.
+func (v *ProjectValuation) Mean() float64 { return stat.Mean(v.values, nil) }
+
+// StdErr returns the standard error of Mean across paths.
+func (v *ProjectValuation) StdErr() float64 {
+	return stat.StdDev(v.values, nil) / math.Sqrt(float64(len(v.values)))
+}
+
+// Quantile returns the empirical p-quantile (0 <= p <= 1) of the project
+// value distribution.
+func (v *ProjectValuation) Quantile(p float64) float64 {
+	sorted := append([]float64(nil), v.values...)
+	sort.Float64s(sorted)
+	return stat.Quantile(p, stat.Empirical, sorted, nil)
+}
+
+// VaR returns the Value at Risk at confidence level alpha: the project
+// value below which only a fraction alpha of paths fall.
+func (v *ProjectValuation) VaR(alpha float64) float64 {
+	return v.Quantile(alpha)
+}
+
+// CVaR returns the Conditional Value at Risk (expected shortfall) at
+// confidence level alpha: the mean project value over the worst alpha
+// fraction of paths.
+func (v *ProjectValuation) CVaR(alpha float64) float64 {
+	sorted := append([]float64(nil), v.values...)
+	sort.Float64s(sorted)
+	cutoff := int(alpha * float64(len(sorted)))
+	if cutoff < 1 {
+		cutoff = 1
+	}
+	return stat.Mean(sorted[:cutoff], nil)
+}
+
+// HistogramBins buckets the project value distribution into n equal-width
+// bins, returning the bin edges (length n+1) and the count in each bin.
+func (v *ProjectValuation) HistogramBins(n int) ([]float64, []int) {
+	min, max := floats.Min(v.values), floats.Max(v.values)
+	width := (max - min) / float64(n)
+
+	edges := make([]float64, n+1)
+	for i := range edges {
+		edges[i] = min + float64(i)*width
+	}
+
+	counts := make([]int, n)
+	for _, x := range v.values {
+		bin := int((x - min) / width)
+		if bin >= n {
+			bin = n - 1
+		} else if bin < 0 {
+			bin = 0
+		}
+		counts[bin]++
+	}
+	return edges, counts
+}
+
+// ProbAbandoned returns the fraction of paths whose simulated
+// cost-to-completion never reached zero within the patent life. Paths the
+// exercise policy abandons, but whose simulated cost still reaches zero,
+// are not counted.
+func (v *ProjectValuation) ProbAbandoned() float64 {
+	return 1 - float64(len(v.completionTimes))/float64(len(v.values))
+}
+
+// ExpectedTimeToCompletion returns the mean time, in years, to complete
+// investment conditional on the project having succeeded. It is NaN if no
+// path completed.
+func (v *ProjectValuation) ExpectedTimeToCompletion() float64 {
+	if len(v.completionTimes) == 0 {
+		return math.NaN()
+	}
+	return stat.Mean(v.completionTimes, nil)
+}